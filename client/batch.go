@@ -0,0 +1,282 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/tssig/tssig-go/tssig"
+)
+
+// batchPayload is the request body sent to the /sign/batch endpoint.
+type batchPayload struct {
+	Digests []string `json:"digests"`
+}
+
+// batchResult is one entry in the /sign/batch response, positionally aligned with batchPayload.Digests.
+type batchResult struct {
+	SignedTimeStamp *tssig.SignedTimeStamp `json:"signedTimeStamp,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+}
+
+// SignBatch signs multiple digests in a single HTTP request against Endpoint + "/sign/batch". It returns a
+// result and an error for each entry in digests, positionally aligned, so a bad digest doesn't fail the whole
+// batch. SignBatch does not retry; callers wanting retries should drive it from Sign's RetryPolicy themselves.
+// It goes through the same circuit breaker, MaxConcurrentRequests semaphore, and MaxResponseSize-bounded
+// decoding as Sign/SignContext, since both hit the same server.
+func (c *Client) SignBatch(digests [][]byte) ([]*tssig.SignedTimeStamp, []error) {
+	results := make([]*tssig.SignedTimeStamp, len(digests))
+	errs := make([]error, len(digests))
+
+	// Validate every digest locally before paying for a round trip; a malformed digest fails right here
+	// instead of depending on the server to report it per-entry. valid holds, in request order, the index into
+	// digests/results/errs that each entry of the outgoing request corresponds to.
+	valid := make([]int, 0, len(digests))
+	for i, digest := range digests {
+		if err := validateDigestLength(digest); err != nil {
+			errs[i] = err
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	if len(valid) == 0 {
+		return results, errs
+	}
+
+	failValid := func(err error) ([]*tssig.SignedTimeStamp, []error) {
+		for _, i := range valid {
+			errs[i] = err
+		}
+		return results, errs
+	}
+
+	if retryAfter, open := c.breaker.isOpen(); open {
+		return failValid(&ErrCircuitOpen{RetryAfter: retryAfter})
+	}
+
+	encodedDigests := make([]string, len(valid))
+	for j, i := range valid {
+		encodedDigests[j] = base64.URLEncoding.EncodeToString(digests[i])
+	}
+
+	jsonPayload, err := json.Marshal(&batchPayload{Digests: encodedDigests})
+	if err != nil {
+		return failValid(err)
+	}
+
+	req, err := http.NewRequest("POST", c.Endpoint+"/sign/batch", bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return failValid(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tssig-client-go")
+
+	// SignBatch takes no context, so there's nothing to cancel the slot wait against.
+	release, err := c.acquireSlot(context.Background())
+	if err != nil {
+		return failValid(err)
+	}
+
+	response, err := c.HttpClient.Do(req)
+	release()
+
+	if err != nil {
+		c.breaker.recordOutcome(nil, err, false, c.FailureThreshold)
+		return failValid(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		err = fmt.Errorf("returned non-200 status code %d", response.StatusCode)
+		c.breaker.recordOutcome(response, err, false, c.FailureThreshold)
+		return failValid(err)
+	}
+
+	limit := c.MaxResponseSize
+	if limit <= 0 {
+		limit = DefaultMaxResponseSize
+	}
+
+	// Batch responses are naturally larger than single-signature ones and fully server-controlled, so they get
+	// the same limit+1 bounded-read treatment sign() uses. We read into a buffer rather than decoding straight
+	// off a limited reader: json.Decoder's internal read-ahead can pull bytes past the logical end of the JSON
+	// value, so its "bytes read" isn't the same thing as "response size".
+	buf, readErr := io.ReadAll(io.LimitReader(response.Body, limit+1))
+	if readErr != nil {
+		c.breaker.recordOutcome(response, readErr, false, c.FailureThreshold)
+		return failValid(readErr)
+	}
+
+	if int64(len(buf)) > limit {
+		err = &ErrResponseTooLarge{Limit: limit}
+		c.breaker.recordOutcome(response, err, false, c.FailureThreshold)
+		return failValid(err)
+	}
+
+	var batchResults []batchResult
+	if decodeErr := json.Unmarshal(buf, &batchResults); decodeErr != nil {
+		c.breaker.recordOutcome(response, decodeErr, false, c.FailureThreshold)
+		return failValid(decodeErr)
+	}
+
+	if len(batchResults) != len(valid) {
+		err = fmt.Errorf("expected %d results, got %d", len(valid), len(batchResults))
+		c.breaker.recordOutcome(response, err, false, c.FailureThreshold)
+		return failValid(err)
+	}
+
+	c.breaker.recordOutcome(response, nil, false, c.FailureThreshold)
+
+	for j, result := range batchResults {
+		i := valid[j]
+		if result.Error != "" {
+			errs[i] = errors.New(result.Error)
+			continue
+		}
+		results[i] = result.SignedTimeStamp
+	}
+
+	return results, errs
+}
+
+//---
+
+// batchRequest is a single digest queued on a Batcher, along with where to deliver its result.
+type batchRequest struct {
+	digest []byte
+	result chan batchResponse
+}
+
+type batchResponse struct {
+	sts *tssig.SignedTimeStamp
+	err error
+}
+
+// Batcher coalesces concurrent Sign calls arriving within a configurable window into a single SignBatch call
+// against the /sign/batch endpoint, so high-throughput callers can amortize HTTP/TLS overhead and server
+// round-trips. Create one with NewBatcher and call Close when done with it.
+type Batcher struct {
+	client *Client
+
+	// BatchWindow is how long the Batcher waits, after the first queued digest, before dispatching the batch.
+	BatchWindow time.Duration
+
+	// MaxBatchSize caps how many digests are dispatched in a single request. A batch that fills up dispatches
+	// immediately without waiting out BatchWindow.
+	MaxBatchSize int
+
+	// Workers is how many goroutines dispatch batches concurrently.
+	Workers int
+
+	requests  chan batchRequest
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	// collectMu lets only one worker at a time collect a batch, so concurrent Sign calls within one window land
+	// in the same batch rather than being split across workers.
+	collectMu sync.Mutex
+}
+
+// NewBatcher creates a Batcher for client with sensible defaults: a 10ms BatchWindow, a MaxBatchSize of 100, and
+// 2*GOMAXPROCS(0) worker goroutines.
+func NewBatcher(client *Client) *Batcher {
+	b := &Batcher{
+		client:       client,
+		BatchWindow:  10 * time.Millisecond,
+		MaxBatchSize: 100,
+		Workers:      2 * runtime.GOMAXPROCS(0),
+		requests:     make(chan batchRequest),
+		done:         make(chan struct{}),
+	}
+
+	for i := 0; i < b.Workers; i++ {
+		b.wg.Add(1)
+		go b.worker()
+	}
+
+	return b
+}
+
+// Sign queues digest to be dispatched as part of the next batch and blocks until its result comes back.
+func (b *Batcher) Sign(digest []byte) (*tssig.SignedTimeStamp, error) {
+	req := batchRequest{digest: digest, result: make(chan batchResponse, 1)}
+
+	select {
+	case b.requests <- req:
+	case <-b.done:
+		return nil, errors.New("batcher is closed")
+	}
+
+	resp := <-req.result
+	return resp.sts, resp.err
+}
+
+// Close stops the Batcher from accepting new Sign calls and waits for its workers to drain. Sign calls already
+// queued when Close is called are still dispatched before the affected worker exits.
+func (b *Batcher) Close() {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+	b.wg.Wait()
+}
+
+// worker collects digests into a batch, bounded by BatchWindow and MaxBatchSize, and dispatches them together.
+// Only one worker collects at a time; dispatching runs concurrently across workers.
+func (b *Batcher) worker() {
+	defer b.wg.Done()
+
+	for {
+		var batch []batchRequest
+
+		b.collectMu.Lock()
+		select {
+		case req := <-b.requests:
+			batch = append(batch, req)
+		case <-b.done:
+			b.collectMu.Unlock()
+			return
+		}
+
+		timer := time.NewTimer(b.BatchWindow)
+	collect:
+		for len(batch) < b.MaxBatchSize {
+			select {
+			case req := <-b.requests:
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			case <-b.done:
+				break collect
+			}
+		}
+		timer.Stop()
+		b.collectMu.Unlock()
+
+		b.dispatch(batch)
+	}
+}
+
+// dispatch signs every digest in batch in one SignBatch call and routes each result back to its requester.
+func (b *Batcher) dispatch(batch []batchRequest) {
+	digests := make([][]byte, len(batch))
+	for i, req := range batch {
+		digests[i] = req.digest
+	}
+
+	results, errs := b.client.SignBatch(digests)
+
+	for i, req := range batch {
+		req.result <- batchResponse{sts: results[i], err: errs[i]}
+	}
+}
@@ -0,0 +1,239 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tssig/tssig-go/tssig"
+)
+
+func validDigest() []byte {
+	return make([]byte, 256/8)
+}
+
+func batchServer(t *testing.T, handle func(digests []string) []batchResult) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload batchPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("server: decoding request body: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(handle(payload.Digests))
+	}))
+}
+
+func TestSignBatch_RejectsMalformedDigestsLocally(t *testing.T) {
+	var gotDigests []string
+
+	server := batchServer(t, func(digests []string) []batchResult {
+		gotDigests = digests
+		results := make([]batchResult, len(digests))
+		for i := range results {
+			results[i] = batchResult{SignedTimeStamp: &tssig.SignedTimeStamp{KeyID: "root-1"}}
+		}
+		return results
+	})
+	defer server.Close()
+
+	c := NewClient(server.URL)
+
+	digests := [][]byte{
+		validDigest(),
+		[]byte("too-short"),
+		validDigest(),
+	}
+
+	results, errs := c.SignBatch(digests)
+
+	if errs[1] == nil {
+		t.Fatalf("errs[1] = nil, want a digest-length error for the malformed digest")
+	}
+	if results[1] != nil {
+		t.Fatalf("results[1] = %v, want nil alongside the validation error", results[1])
+	}
+
+	if errs[0] != nil || errs[2] != nil {
+		t.Fatalf("errs = %v, want nil for the two valid digests", errs)
+	}
+	if results[0] == nil || results[2] == nil {
+		t.Fatalf("results = %v, want non-nil for the two valid digests", results)
+	}
+
+	if len(gotDigests) != 2 {
+		t.Fatalf("server received %d digests, want 2: the malformed digest should never be sent", len(gotDigests))
+	}
+}
+
+func TestSignBatch_AllDigestsInvalidSkipsTheRequest(t *testing.T) {
+	called := false
+	server := batchServer(t, func(digests []string) []batchResult {
+		called = true
+		return nil
+	})
+	defer server.Close()
+
+	c := NewClient(server.URL)
+
+	_, errs := c.SignBatch([][]byte{[]byte("bad"), []byte("also-bad")})
+
+	if errs[0] == nil || errs[1] == nil {
+		t.Fatalf("errs = %v, want both populated", errs)
+	}
+	if called {
+		t.Fatalf("server was called, want no request when every digest is malformed")
+	}
+}
+
+func TestSignBatch_MapsPerEntryServerErrors(t *testing.T) {
+	server := batchServer(t, func(digests []string) []batchResult {
+		results := make([]batchResult, len(digests))
+		for i := range digests {
+			if i == 1 {
+				results[i] = batchResult{Error: "server: signing failed"}
+				continue
+			}
+			results[i] = batchResult{SignedTimeStamp: &tssig.SignedTimeStamp{KeyID: "root-1"}}
+		}
+		return results
+	})
+	defer server.Close()
+
+	c := NewClient(server.URL)
+
+	digests := [][]byte{validDigest(), validDigest(), validDigest()}
+	results, errs := c.SignBatch(digests)
+
+	if errs[1] == nil || errs[1].Error() != "server: signing failed" {
+		t.Fatalf("errs[1] = %v, want the per-entry server error", errs[1])
+	}
+	if errs[0] != nil || errs[2] != nil {
+		t.Fatalf("errs = %v, want nil for the two successful entries", errs)
+	}
+	if results[0] == nil || results[2] == nil || results[1] != nil {
+		t.Fatalf("results = %v, want set only for the two successful entries", results)
+	}
+}
+
+func TestBatcher_SignDispatchesInOneRequest(t *testing.T) {
+	var mu sync.Mutex
+	var requestSizes []int
+
+	server := batchServer(t, func(digests []string) []batchResult {
+		mu.Lock()
+		requestSizes = append(requestSizes, len(digests))
+		mu.Unlock()
+
+		results := make([]batchResult, len(digests))
+		for i, d := range digests {
+			results[i] = batchResult{SignedTimeStamp: &tssig.SignedTimeStamp{KeyID: d}}
+		}
+		return results
+	})
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	b := NewBatcher(c)
+	b.BatchWindow = 20 * time.Millisecond
+	defer b.Close()
+
+	const n = 5
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := b.Sign(validDigest())
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Sign()[%d] error = %v, want nil", i, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requestSizes) != 1 || requestSizes[0] != n {
+		t.Fatalf("requestSizes = %v, want a single batch of %d", requestSizes, n)
+	}
+}
+
+func TestBatcher_DispatchesImmediatelyOnceMaxBatchSizeReached(t *testing.T) {
+	var mu sync.Mutex
+	var requestSizes []int
+
+	server := batchServer(t, func(digests []string) []batchResult {
+		mu.Lock()
+		requestSizes = append(requestSizes, len(digests))
+		mu.Unlock()
+
+		results := make([]batchResult, len(digests))
+		for i, d := range digests {
+			results[i] = batchResult{SignedTimeStamp: &tssig.SignedTimeStamp{KeyID: d}}
+		}
+		return results
+	})
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	b := NewBatcher(c)
+	b.BatchWindow = time.Minute // long enough that only MaxBatchSize should trigger dispatch
+	b.MaxBatchSize = 2
+	defer b.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.Sign(validDigest()); err != nil {
+				t.Errorf("Sign() error = %v, want nil", err)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Sign() calls did not return once MaxBatchSize was reached")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requestSizes) != 1 || requestSizes[0] != 2 {
+		t.Fatalf("requestSizes = %v, want a single batch of 2", requestSizes)
+	}
+}
+
+func TestBatcher_CloseRejectsNewSignCalls(t *testing.T) {
+	server := batchServer(t, func(digests []string) []batchResult {
+		return make([]batchResult, len(digests))
+	})
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	b := NewBatcher(c)
+	b.Close()
+
+	if _, err := b.Sign(validDigest()); err == nil {
+		t.Fatalf("Sign() after Close() error = nil, want an error")
+	}
+}
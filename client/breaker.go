@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker is open; the request was failed fast without being sent.
+type ErrCircuitOpen struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit breaker is open, retry after %s", e.RetryAfter)
+}
+
+// Stats is a snapshot of the counters a Client accumulates across all Sign/SignContext calls, for observability.
+type Stats struct {
+	Successes    int64
+	Retries      int64
+	BreakerTrips int64
+}
+
+// breakerState is the circuit breaker's mutable state. Its zero value is a closed breaker with no history, so it
+// needs no constructor and can be embedded directly in Client.
+type breakerState struct {
+	mu sync.Mutex
+
+	consecutiveFailures int
+	openUntil           time.Time
+
+	successes    int64
+	retries      int64
+	breakerTrips int64
+}
+
+// isOpen reports whether the breaker is currently open, and if so, how long until it's expected to close.
+func (b *breakerState) isOpen() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return 0, false
+	}
+
+	remaining := time.Until(b.openUntil)
+	if remaining <= 0 {
+		b.openUntil = time.Time{}
+		b.consecutiveFailures = 0
+		return 0, false
+	}
+
+	return remaining, true
+}
+
+// recordOutcome updates the breaker's bookkeeping after one attempt, tripping the breaker open once
+// threshold consecutive failures have been seen. threshold <= 0 disables tripping.
+func (b *breakerState) recordOutcome(response *http.Response, err error, retrying bool, threshold int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.successes++
+		b.consecutiveFailures = 0
+		return
+	}
+
+	if retrying {
+		b.retries++
+	}
+
+	b.consecutiveFailures++
+	if threshold > 0 && b.consecutiveFailures >= threshold {
+		b.breakerTrips++
+		b.openUntil = time.Now().Add(coolDown(response))
+	}
+}
+
+// defaultCoolDown is used when a 429/5xx response carries no usable Retry-After or X-RateLimit-Reset header.
+const defaultCoolDown = 5 * time.Second
+
+// coolDown derives how long the breaker should stay open from the server's Retry-After or X-RateLimit-Reset
+// response headers, falling back to defaultCoolDown when neither is present or parseable.
+func coolDown(response *http.Response) time.Duration {
+	if response == nil {
+		return defaultCoolDown
+	}
+
+	if v := response.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if when, err := http.ParseTime(v); err == nil {
+			if d := time.Until(when); d > 0 {
+				return d
+			}
+		}
+	}
+
+	if v := response.Header.Get("X-RateLimit-Reset"); v != "" {
+		if epochSeconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			if d := time.Until(time.Unix(epochSeconds, 0)); d > 0 {
+				return d
+			}
+		}
+	}
+
+	return defaultCoolDown
+}
+
+// Stats returns a snapshot of the success/retry/breaker-trip counters accumulated across all Sign/SignContext
+// calls made through c.
+func (c *Client) Stats() Stats {
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+
+	return Stats{
+		Successes:    c.breaker.successes,
+		Retries:      c.breaker.retries,
+		BreakerTrips: c.breaker.breakerTrips,
+	}
+}
+
+// acquireSlot blocks until a concurrency slot is available or ctx is done, whichever comes first, lazily sizing
+// the semaphore from Client.MaxConcurrentRequests on first use. On success it returns a func that releases the
+// slot and a nil error; when MaxConcurrentRequests is unset, that func is a no-op. If ctx is done first, it
+// returns a no-op release func and ctx.Err().
+func (c *Client) acquireSlot(ctx context.Context) (func(), error) {
+	if c.MaxConcurrentRequests <= 0 {
+		return func() {}, nil
+	}
+
+	c.semOnce.Do(func() {
+		c.sem = make(chan struct{}, c.MaxConcurrentRequests)
+	})
+
+	select {
+	case c.sem <- struct{}{}:
+		return func() { <-c.sem }, nil
+	case <-ctx.Done():
+		return func() {}, ctx.Err()
+	}
+}
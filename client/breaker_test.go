@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBreakerState_TripsAfterThreshold(t *testing.T) {
+	var b breakerState
+
+	failErr := errors.New("boom")
+
+	// Two failures shy of the threshold: still closed.
+	b.recordOutcome(nil, failErr, false, 3)
+	b.recordOutcome(nil, failErr, false, 3)
+
+	if _, open := b.isOpen(); open {
+		t.Fatalf("isOpen() = true after 2 of 3 failures, want false")
+	}
+
+	// Third consecutive failure trips the breaker.
+	b.recordOutcome(nil, failErr, false, 3)
+
+	remaining, open := b.isOpen()
+	if !open {
+		t.Fatalf("isOpen() = false after reaching threshold, want true")
+	}
+	if remaining <= 0 {
+		t.Fatalf("isOpen() remaining = %s, want > 0", remaining)
+	}
+	if b.breakerTrips != 1 {
+		t.Fatalf("breakerTrips = %d, want 1", b.breakerTrips)
+	}
+}
+
+func TestBreakerState_SuccessResetsConsecutiveFailures(t *testing.T) {
+	var b breakerState
+	failErr := errors.New("boom")
+
+	b.recordOutcome(nil, failErr, false, 3)
+	b.recordOutcome(nil, failErr, false, 3)
+	b.recordOutcome(nil, nil, false, 3) // success clears the streak
+
+	b.recordOutcome(nil, failErr, false, 3)
+	b.recordOutcome(nil, failErr, false, 3)
+
+	if _, open := b.isOpen(); open {
+		t.Fatalf("isOpen() = true, want false: the success should have reset the consecutive-failure count")
+	}
+	if b.breakerTrips != 0 {
+		t.Fatalf("breakerTrips = %d, want 0", b.breakerTrips)
+	}
+}
+
+func TestBreakerState_ClosesOnceCoolDownElapses(t *testing.T) {
+	var b breakerState
+	b.openUntil = time.Now().Add(-time.Millisecond) // already elapsed
+	b.consecutiveFailures = 5
+
+	if _, open := b.isOpen(); open {
+		t.Fatalf("isOpen() = true after openUntil has passed, want false")
+	}
+	if b.consecutiveFailures != 0 {
+		t.Fatalf("consecutiveFailures = %d after closing, want 0", b.consecutiveFailures)
+	}
+}
+
+func TestBreakerState_ThresholdDisabled(t *testing.T) {
+	var b breakerState
+	failErr := errors.New("boom")
+
+	for i := 0; i < 10; i++ {
+		b.recordOutcome(nil, failErr, false, 0)
+	}
+
+	if _, open := b.isOpen(); open {
+		t.Fatalf("isOpen() = true with threshold <= 0, want false: tripping should be disabled")
+	}
+}
+
+func TestCoolDown(t *testing.T) {
+	header := func(key, value string) *http.Response {
+		resp := &http.Response{Header: make(http.Header)}
+		resp.Header.Set(key, value)
+		return resp
+	}
+
+	tests := []struct {
+		name     string
+		response *http.Response
+		want     time.Duration
+	}{
+		{name: "nil response falls back to default", response: nil, want: defaultCoolDown},
+		{name: "no relevant headers falls back to default", response: &http.Response{Header: make(http.Header)}, want: defaultCoolDown},
+		{name: "Retry-After in seconds", response: header("Retry-After", "30"), want: 30 * time.Second},
+		{name: "unparseable Retry-After falls back to default", response: header("Retry-After", "not-a-number"), want: defaultCoolDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := coolDown(tt.response); got != tt.want {
+				t.Fatalf("coolDown() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Retry-After as HTTP date", func(t *testing.T) {
+		when := time.Now().Add(45 * time.Second)
+		resp := header("Retry-After", when.UTC().Format(http.TimeFormat))
+
+		got := coolDown(resp)
+		if got <= 0 || got > 45*time.Second {
+			t.Fatalf("coolDown() = %s, want roughly 45s", got)
+		}
+	})
+
+	t.Run("X-RateLimit-Reset as epoch seconds", func(t *testing.T) {
+		when := time.Now().Add(20 * time.Second)
+		resp := header("X-RateLimit-Reset", strconv.FormatInt(when.Unix(), 10))
+
+		got := coolDown(resp)
+		if got <= 0 || got > 20*time.Second {
+			t.Fatalf("coolDown() = %s, want roughly 20s", got)
+		}
+	})
+}
+
+func TestAcquireSlot_BlocksUntilSlotFrees(t *testing.T) {
+	c := &Client{MaxConcurrentRequests: 1}
+
+	release, err := c.acquireSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquireSlot() error = %v, want nil", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		release2, err := c.acquireSlot(context.Background())
+		if err != nil {
+			t.Errorf("second acquireSlot() error = %v, want nil", err)
+			return
+		}
+		release2()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatalf("second acquireSlot() returned before the first slot was released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatalf("second acquireSlot() did not return after the slot was released")
+	}
+}
+
+func TestAcquireSlot_HonorsContextCancellation(t *testing.T) {
+	c := &Client{MaxConcurrentRequests: 1}
+
+	release, err := c.acquireSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquireSlot() error = %v, want nil", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = c.acquireSlot(ctx)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquireSlot() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("acquireSlot() took %s to honor ctx.Done(), want close to the 50ms ctx deadline", elapsed)
+	}
+}
+
+func TestAcquireSlot_UnboundedWhenMaxConcurrentRequestsUnset(t *testing.T) {
+	c := &Client{}
+
+	release, err := c.acquireSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquireSlot() error = %v, want nil", err)
+	}
+	release()
+}
@@ -2,19 +2,34 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/tssig/tssig-go/client/verify"
 	"github.com/tssig/tssig-go/tssig"
+	"io"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 )
 
-// MaxHttpDownloadSize Maximum size, in bytes, we'll accept.
-const MaxHttpDownloadSize = 768
+// DefaultMaxResponseSize is the response size limit used when Client.MaxResponseSize is zero.
+const DefaultMaxResponseSize = 64 * 1024
+
+//---
+
+// ErrResponseTooLarge is returned by sign when the server's response exceeds Client.MaxResponseSize.
+type ErrResponseTooLarge struct {
+	Limit int64
+}
+
+func (e *ErrResponseTooLarge) Error() string {
+	return fmt.Sprintf("the maximum allowed response size is %d bytes. the returned response is bigger", e.Limit)
+}
 
 //---
 
@@ -34,6 +49,61 @@ type payload struct {
 
 //---
 
+// RetryPolicy decides how a Client retries failed attempts: what delay to use between attempts, and which
+// responses/errors are worth retrying at all. Client falls back to defaultRetryPolicy when RetryPolicy is nil.
+type RetryPolicy interface {
+	// BackOff returns a fresh backoff.BackOff to drive the retry loop for a single Sign/SignContext call.
+	BackOff() backoff.BackOff
+
+	// ShouldRetry reports whether the given response/error combination should trigger another attempt. resp is
+	// nil when the request failed before a response was received.
+	ShouldRetry(resp *http.Response, err error) bool
+}
+
+// defaultRetryPolicy is the RetryPolicy used when Client.RetryPolicy is nil. It exponentially backs off using the
+// client's InitialInterval/MaxInterval/Multiplier/RandomizationFactor fields, and retries 429/5xx responses plus
+// any error classified as Retryable, unless the client's ShouldRetry hook overrides that decision.
+type defaultRetryPolicy struct {
+	client *Client
+}
+
+func (p *defaultRetryPolicy) BackOff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	if p.client.InitialInterval > 0 {
+		b.InitialInterval = p.client.InitialInterval
+	}
+	if p.client.MaxInterval > 0 {
+		b.MaxInterval = p.client.MaxInterval
+	}
+	if p.client.Multiplier > 0 {
+		b.Multiplier = p.client.Multiplier
+	}
+	if p.client.RandomizationFactor > 0 {
+		b.RandomizationFactor = p.client.RandomizationFactor
+	}
+	b.MaxElapsedTime = p.client.TotalTimeout
+	return b
+}
+
+func (p *defaultRetryPolicy) ShouldRetry(resp *http.Response, err error) bool {
+	if p.client.ShouldRetry != nil {
+		return p.client.ShouldRetry(resp, err)
+	}
+
+	var retryable Retryable
+	if errors.As(err, &retryable) || os.IsTimeout(err) {
+		return true
+	}
+
+	return resp != nil && (resp.StatusCode == 429 || resp.StatusCode >= 500)
+}
+
+//---
+
+// NotifyFunc is called after a failed attempt, before the next one is scheduled. attempt is the 1-indexed number
+// of the attempt that just failed.
+type NotifyFunc func(attempt int, err error, delay time.Duration)
+
 type Client struct {
 	// The TSSig server's URL.
 	Endpoint string
@@ -41,10 +111,55 @@ type Client struct {
 	// TotalTimeout denotes the total time that we'll keep retrying to get a successful response, including retries.
 	TotalTimeout time.Duration
 
-	// Optional function that's updated when a retry occurs.
-	Notify backoff.Notify
+	// PerAttemptTimeout, if non-zero, bounds each individual attempt independently of TotalTimeout. It's applied
+	// as its own context deadline, so a single slow attempt can't eat the whole retry budget.
+	PerAttemptTimeout time.Duration
+
+	// InitialInterval, MaxInterval, Multiplier and RandomizationFactor configure the exponential backoff used by
+	// the default RetryPolicy. They're ignored when RetryPolicy is set to a custom implementation. Zero values
+	// fall back to backoff.NewExponentialBackOff's own defaults.
+	InitialInterval     time.Duration
+	MaxInterval         time.Duration
+	Multiplier          float64
+	RandomizationFactor float64
+
+	// RetryPolicy controls backoff timing and which failures are retried. Defaults to defaultRetryPolicy, which
+	// is driven by the fields above and ShouldRetry.
+	RetryPolicy RetryPolicy
+
+	// ShouldRetry, if set, overrides the default policy's decision on whether a given response/error combination
+	// warrants a retry. It's consulted by defaultRetryPolicy.ShouldRetry and ignored by a custom RetryPolicy.
+	ShouldRetry func(resp *http.Response, err error) bool
+
+	// Notify, if set, is called after each failed attempt with the attempt number, the error, and the delay
+	// before the next attempt.
+	Notify NotifyFunc
+
+	// MaxResponseSize caps how many bytes of the server's response we'll read before giving up with
+	// ErrResponseTooLarge. Zero means DefaultMaxResponseSize.
+	MaxResponseSize int64
+
+	// Verify, if true, makes Sign and SignContext validate every response through Verifier before returning it,
+	// failing with Verifier's error instead of a forged or misattributed SignedTimeStamp. Requires Verifier.
+	Verify bool
+
+	// Verifier performs the signature verification used when Verify is true.
+	Verifier *verify.Verifier
+
+	// FailureThreshold is how many consecutive failed attempts trip the circuit breaker open, after which new
+	// requests fail fast with ErrCircuitOpen until the server's Retry-After/X-RateLimit-Reset cool-down elapses.
+	// Zero disables the breaker.
+	FailureThreshold int
+
+	// MaxConcurrentRequests caps how many Sign/SignContext calls may have an HTTP request in flight at once.
+	// Zero means unlimited.
+	MaxConcurrentRequests int
 
 	HttpClient *http.Client
+
+	breaker breakerState
+	sem     chan struct{}
+	semOnce sync.Once
 }
 
 // NewClient Creates a new Client with sensible defaults.
@@ -57,61 +172,122 @@ func NewClient(endpoint string) *Client {
 	}
 }
 
-//---
+// retryPolicy returns c.RetryPolicy, falling back to the default policy bound to c.
+func (c *Client) retryPolicy() RetryPolicy {
+	if c.RetryPolicy != nil {
+		return c.RetryPolicy
+	}
+	return &defaultRetryPolicy{client: c}
+}
 
-// Sign Initiates the request to sign the digest, with Exponential BackOff retries in place.
-func (c *Client) Sign(digest []byte) (*tssig.SignedTimeStamp, error) {
+//---
 
+// validateDigestLength checks that digest is exactly 224, 256, 384, or 512 bits long, the sizes the TSSig
+// server accepts, returning a descriptive error otherwise.
+func validateDigestLength(digest []byte) error {
 	switch length := len(digest); length {
 	case 224 / 8:
 	case 256 / 8:
 	case 384 / 8:
 	case 512 / 8:
 	default:
-		return nil, fmt.Errorf(
+		return fmt.Errorf(
 			"digest must be exactly 224, 256, 384, or 512 bits. %d bits found",
 			len(digest)*8,
 		)
 	}
 
-	// ---
+	return nil
+}
 
-	exponentialBackOff := backoff.NewExponentialBackOff()
-	exponentialBackOff.MaxElapsedTime = c.TotalTimeout
+//---
 
-	var retryable Retryable
+// Sign Initiates the request to sign the digest, with Exponential BackOff retries in place.
+func (c *Client) Sign(digest []byte) (*tssig.SignedTimeStamp, error) {
+	return c.SignContext(context.Background(), digest)
+}
+
+// SignContext Initiates the request to sign the digest, with Exponential BackOff retries in place. The supplied
+// ctx bounds the entire call, including all retries; cancelling it aborts the backoff loop immediately. Use
+// Client.PerAttemptTimeout to additionally bound each individual attempt.
+func (c *Client) SignContext(ctx context.Context, digest []byte) (*tssig.SignedTimeStamp, error) {
+
+	if err := validateDigestLength(digest); err != nil {
+		return nil, err
+	}
+
+	// ---
+
+	policy := c.retryPolicy()
+	attempt := 0
 
 	return backoff.RetryNotifyWithData(
 		func() (*tssig.SignedTimeStamp, error) {
-			sts, err := c.sign(digest)
+			attempt++
+
+			if retryAfter, open := c.breaker.isOpen(); open {
+				return nil, backoff.Permanent(&ErrCircuitOpen{RetryAfter: retryAfter})
+			}
 
-			// Check if the error is Retryable, or a timeout...
-			if errors.As(err, &retryable) || os.IsTimeout(err) {
+			attemptCtx := ctx
+			if c.PerAttemptTimeout > 0 {
+				var cancel context.CancelFunc
+				attemptCtx, cancel = context.WithTimeout(ctx, c.PerAttemptTimeout)
+				defer cancel()
+			}
+
+			release, err := c.acquireSlot(attemptCtx)
+			if err != nil {
+				return nil, backoff.Permanent(err)
+			}
+
+			sts, response, err := c.sign(attemptCtx, digest)
+			release()
+
+			if err == nil && c.Verify {
+				if c.Verifier == nil {
+					return nil, backoff.Permanent(fmt.Errorf("client: Verify is true but Verifier is nil"))
+				}
+				if verifyErr := c.Verifier.Verify(digest, sts); verifyErr != nil {
+					c.breaker.recordOutcome(response, verifyErr, false, c.FailureThreshold)
+					return nil, backoff.Permanent(verifyErr)
+				}
+			}
+
+			retrying := policy.ShouldRetry(response, err)
+			c.breaker.recordOutcome(response, err, retrying, c.FailureThreshold)
+
+			if retrying {
 				return sts, err
 			}
 
-			// If the error is not Retryable, or a timeout, assume it's Permanent.
+			// Not worth retrying: assume it's Permanent.
 			return sts, backoff.Permanent(err)
 		},
-		exponentialBackOff,
-		c.Notify,
+		backoff.WithContext(policy.BackOff(), ctx),
+		func(err error, delay time.Duration) {
+			if c.Notify != nil {
+				c.Notify(attempt, err, delay)
+			}
+		},
 	)
 }
 
-// sign Perform the actual HTTP request to retrieve a Signed Time Stamp.
-func (c *Client) sign(digest []byte) (*tssig.SignedTimeStamp, error) {
+// sign Perform the actual HTTP request to retrieve a Signed Time Stamp. The returned *http.Response is non-nil
+// whenever a response was received, even when err is also non-nil, so callers can inspect its status code.
+func (c *Client) sign(ctx context.Context, digest []byte) (*tssig.SignedTimeStamp, *http.Response, error) {
 	requestPayload := &payload{
 		Digest: base64.URLEncoding.EncodeToString(digest),
 	}
 
 	jsonPayload, err := json.Marshal(requestPayload)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	req, err := http.NewRequest("POST", c.Endpoint, bytes.NewBuffer(jsonPayload))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.Endpoint, bytes.NewBuffer(jsonPayload))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -119,43 +295,42 @@ func (c *Client) sign(digest []byte) (*tssig.SignedTimeStamp, error) {
 
 	response, err := c.HttpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode == 429 || response.StatusCode >= 500 {
-		return nil, Retryable(fmt.Sprintf("returned non-200 status code %d. we can retry", response.StatusCode))
+		return nil, response, Retryable(fmt.Sprintf("returned non-200 status code %d. we can retry", response.StatusCode))
 	} else if response.StatusCode != 200 {
-		return nil, fmt.Errorf("returned non-200 status code %d", response.StatusCode)
+		return nil, response, fmt.Errorf("returned non-200 status code %d", response.StatusCode)
 	}
 
-	if response.ContentLength > MaxHttpDownloadSize {
-		return nil, fmt.Errorf(
-			"the maximum allowed response size is %d bytes. the returned response is %d bytes",
-			MaxHttpDownloadSize,
-			response.ContentLength,
-		)
+	limit := c.MaxResponseSize
+	if limit <= 0 {
+		limit = DefaultMaxResponseSize
+	}
+
+	if response.ContentLength > limit {
+		return nil, response, &ErrResponseTooLarge{Limit: limit}
 	}
 
-	// We add 1 to detect responses' that are too large.
-	result := make([]byte, MaxHttpDownloadSize+1)
-	n, err := response.Body.Read(result)
+	// Read at most limit+1 bytes so we can tell a response that exactly fills the limit apart from one that
+	// overflows it. We read into a bounded buffer rather than decoding straight off a limited reader: a
+	// json.Decoder pulls bytes off the wire in its own internal chunks, which can run past the logical end of
+	// the JSON value, so the decoder's "bytes read" isn't the same thing as "response size".
+	buf, err := io.ReadAll(io.LimitReader(response.Body, limit+1))
 	if err != nil {
-		return nil, err
+		return nil, response, err
 	}
 
-	if n > MaxHttpDownloadSize {
-		return nil, fmt.Errorf(
-			"the maximum allowed response size is %d bytes. the returned response is bigger",
-			MaxHttpDownloadSize,
-		)
+	if int64(len(buf)) > limit {
+		return nil, response, &ErrResponseTooLarge{Limit: limit}
 	}
 
 	sts := &tssig.SignedTimeStamp{}
-	err = json.Unmarshal(result[:n], sts)
-	if err != nil {
-		return nil, err
+	if err := json.Unmarshal(buf, sts); err != nil {
+		return nil, response, err
 	}
 
-	return sts, nil
+	return sts, response, nil
 }
@@ -0,0 +1,189 @@
+// Package verify validates tssig.SignedTimeStamp responses against a pinned, periodically refreshed set of
+// trust roots, so callers don't have to trust the TSSig server's TLS certificate alone.
+package verify
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/tssig/tssig-go/tssig"
+)
+
+// TrustRoot is a single pinned signing key, identified by KeyID.
+type TrustRoot struct {
+	KeyID     string `json:"keyId"`
+	PublicKey []byte `json:"publicKey"`
+}
+
+// ErrUntrustedKey is returned when a SignedTimeStamp was signed by a KeyID that isn't in the current trust roots.
+type ErrUntrustedKey struct {
+	KeyID string
+}
+
+func (e *ErrUntrustedKey) Error() string {
+	return fmt.Sprintf("signed time stamp was signed by untrusted key %q", e.KeyID)
+}
+
+// ErrInvalidSignature is returned when a SignedTimeStamp's signature doesn't verify against its claimed trust root.
+var ErrInvalidSignature = errors.New("signed time stamp signature is invalid")
+
+// Verifier validates SignedTimeStamp responses against a pinned, periodically refreshed set of trust roots. The
+// zero value is not usable; create one with NewVerifier.
+type Verifier struct {
+	// TrustRootsURL is fetched to (re)populate the trust root set.
+	TrustRootsURL string
+
+	// CacheDir, if set, persists fetched trust roots to disk so a Verifier can still validate signatures after
+	// a restart even if TrustRootsURL is temporarily unreachable.
+	CacheDir string
+
+	// RefreshInterval is how long a fetched trust root set is trusted before being re-fetched. Zero disables
+	// automatic refresh after the initial fetch.
+	RefreshInterval time.Duration
+
+	HttpClient *http.Client
+
+	mu          sync.Mutex
+	roots       map[string]TrustRoot
+	lastFetched time.Time
+}
+
+// NewVerifier creates a Verifier that fetches its trust roots from trustRootsURL, refreshing them hourly.
+func NewVerifier(trustRootsURL string) *Verifier {
+	return &Verifier{
+		TrustRootsURL:   trustRootsURL,
+		RefreshInterval: time.Hour,
+		HttpClient:      &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Verify checks that sts is a validly signed time stamp for digest, signed by one of the current trust roots.
+func (v *Verifier) Verify(digest []byte, sts *tssig.SignedTimeStamp) error {
+	roots, err := v.currentRoots()
+	if err != nil {
+		return err
+	}
+
+	root, ok := roots[sts.KeyID]
+	if !ok {
+		return &ErrUntrustedKey{KeyID: sts.KeyID}
+	}
+
+	if len(root.PublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("trust root %q has an invalid public key", sts.KeyID)
+	}
+
+	if !ed25519.Verify(root.PublicKey, digest, sts.Signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// currentRoots returns the cached trust roots, refreshing them first if they're stale or haven't been fetched yet.
+func (v *Verifier) currentRoots() (map[string]TrustRoot, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.roots != nil && (v.RefreshInterval <= 0 || time.Since(v.lastFetched) < v.RefreshInterval) {
+		return v.roots, nil
+	}
+
+	roots, err := v.fetch()
+	if err != nil {
+		if v.roots != nil {
+			// Keep serving the stale set rather than failing verification outright on a transient fetch error.
+			return v.roots, nil
+		}
+
+		roots, err = v.loadCache()
+		if err != nil {
+			return nil, err
+		}
+	} else if v.CacheDir != "" {
+		// Persisting the cache is best-effort: a disk error here shouldn't fail verification when we already
+		// have a perfectly good freshly-fetched set of roots to use.
+		_ = v.saveCache(roots)
+	}
+
+	v.roots = roots
+	v.lastFetched = time.Now()
+	return v.roots, nil
+}
+
+func (v *Verifier) fetch() (map[string]TrustRoot, error) {
+	client := v.HttpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Get(v.TrustRootsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("fetching trust roots: returned non-200 status code %d", response.StatusCode)
+	}
+
+	return decodeRoots(response.Body)
+}
+
+func (v *Verifier) cachePath() string {
+	return filepath.Join(v.CacheDir, "trust-roots.json")
+}
+
+func (v *Verifier) loadCache() (map[string]TrustRoot, error) {
+	if v.CacheDir == "" {
+		return nil, errors.New("no trust roots available: fetch failed and no CacheDir is configured")
+	}
+
+	f, err := os.Open(v.cachePath())
+	if err != nil {
+		return nil, fmt.Errorf("no trust roots available: %w", err)
+	}
+	defer f.Close()
+
+	return decodeRoots(f)
+}
+
+func (v *Verifier) saveCache(roots map[string]TrustRoot) error {
+	if err := os.MkdirAll(v.CacheDir, 0o755); err != nil {
+		return err
+	}
+
+	list := make([]TrustRoot, 0, len(roots))
+	for _, root := range roots {
+		list = append(list, root)
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(v.cachePath(), data, 0o644)
+}
+
+func decodeRoots(r io.Reader) (map[string]TrustRoot, error) {
+	var list []TrustRoot
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, err
+	}
+
+	roots := make(map[string]TrustRoot, len(list))
+	for _, root := range list {
+		roots[root.KeyID] = root
+	}
+
+	return roots, nil
+}
@@ -0,0 +1,152 @@
+package verify
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tssig/tssig-go/tssig"
+)
+
+func mustKeyPair(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generating key pair: %v", err)
+	}
+	return pub, priv
+}
+
+func trustRootsServer(roots []TrustRoot) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(roots)
+	}))
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	pub, priv := mustKeyPair(t)
+	digest := []byte("0123456789abcdef0123456789abcdef")
+
+	server := trustRootsServer([]TrustRoot{{KeyID: "root-1", PublicKey: pub}})
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+
+	tests := []struct {
+		name    string
+		sts     *tssig.SignedTimeStamp
+		wantErr error // checked with errors.As/errors.Is; nil means Verify must succeed
+	}{
+		{
+			name: "valid signature",
+			sts:  &tssig.SignedTimeStamp{KeyID: "root-1", Signature: ed25519.Sign(priv, digest)},
+		},
+		{
+			name:    "untrusted key",
+			sts:     &tssig.SignedTimeStamp{KeyID: "root-2", Signature: ed25519.Sign(priv, digest)},
+			wantErr: &ErrUntrustedKey{KeyID: "root-2"},
+		},
+		{
+			name:    "tampered digest",
+			sts:     &tssig.SignedTimeStamp{KeyID: "root-1", Signature: ed25519.Sign(priv, []byte("a different digest entirely"))},
+			wantErr: ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Verify(digest, tt.sts)
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify() = %v, want nil", err)
+				}
+				return
+			}
+
+			var untrusted *ErrUntrustedKey
+			if errors.As(tt.wantErr, &untrusted) {
+				var got *ErrUntrustedKey
+				if !errors.As(err, &got) || got.KeyID != untrusted.KeyID {
+					t.Fatalf("Verify() = %v, want ErrUntrustedKey{KeyID: %q}", err, untrusted.KeyID)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestVerifier_StaleCacheFallback checks that a transient fetch failure, once a trust root set has already been
+// fetched, falls back to the stale in-memory set rather than failing verification outright.
+func TestVerifier_StaleCacheFallback(t *testing.T) {
+	pub, priv := mustKeyPair(t)
+	digest := []byte("0123456789abcdef0123456789abcdef")
+
+	var up atomic.Bool
+	up.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]TrustRoot{{KeyID: "root-1", PublicKey: pub}})
+	}))
+	defer server.Close()
+
+	v := NewVerifier(server.URL)
+	v.RefreshInterval = time.Millisecond
+
+	sts := &tssig.SignedTimeStamp{KeyID: "root-1", Signature: ed25519.Sign(priv, digest)}
+
+	if err := v.Verify(digest, sts); err != nil {
+		t.Fatalf("initial Verify() = %v, want nil", err)
+	}
+
+	up.Store(false)
+	time.Sleep(2 * time.Millisecond) // let RefreshInterval elapse so the next Verify re-fetches
+
+	if err := v.Verify(digest, sts); err != nil {
+		t.Fatalf("Verify() during server outage = %v, want nil (should fall back to the stale set)", err)
+	}
+}
+
+// TestVerifier_DiskCacheFallback checks that a Verifier with CacheDir set can still validate signatures after a
+// restart (a fresh Verifier instance) even if TrustRootsURL has become unreachable.
+func TestVerifier_DiskCacheFallback(t *testing.T) {
+	pub, priv := mustKeyPair(t)
+	digest := []byte("0123456789abcdef0123456789abcdef")
+
+	server := trustRootsServer([]TrustRoot{{KeyID: "root-1", PublicKey: pub}})
+
+	dir := t.TempDir()
+	v := NewVerifier(server.URL)
+	v.CacheDir = dir
+
+	sts := &tssig.SignedTimeStamp{KeyID: "root-1", Signature: ed25519.Sign(priv, digest)}
+
+	if err := v.Verify(digest, sts); err != nil {
+		t.Fatalf("initial Verify() = %v, want nil", err)
+	}
+
+	server.Close() // TrustRootsURL is now unreachable, simulating a restart with no network access
+
+	fresh := NewVerifier(server.URL)
+	fresh.CacheDir = dir
+
+	if err := fresh.Verify(digest, sts); err != nil {
+		t.Fatalf("Verify() from disk cache = %v, want nil", err)
+	}
+}